@@ -2,6 +2,10 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -10,7 +14,13 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/codegangsta/cli"
@@ -45,8 +55,14 @@ var (
 	email    string
 )
 
+// UrlHook may optionally carry a checksum pin as a URL fragment, e.g.
+// https://example.com/pre-commit.sh#sha256=abcdef... (sha512= also
+// accepted). When present, Install refuses to write the script unless the
+// downloaded bytes hash to the pinned digest.
 type UrlHook struct {
-	url *url.URL
+	url        *url.URL
+	digestAlgo string
+	digestHex  string
 }
 
 func ParseUrlHook(s string) (Hook, error) {
@@ -57,7 +73,43 @@ func ParseUrlHook(s string) (Hook, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &UrlHook{url}, err
+
+	h := &UrlHook{url: url}
+	if url.Fragment != "" {
+		algo, digest, err := parseDigestFragment(url.Fragment)
+		if err != nil {
+			return nil, err
+		}
+		h.digestAlgo = algo
+		h.digestHex = digest
+	}
+	return h, nil
+}
+
+func parseDigestFragment(fragment string) (string, string, error) {
+	parts := strings.SplitN(fragment, "=", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("malformed checksum fragment '%s'", fragment)
+	}
+	switch parts[0] {
+	case "sha256", "sha512":
+		return parts[0], parts[1], nil
+	default:
+		return "", "", fmt.Errorf("unsupported digest algorithm '%s'", parts[0])
+	}
+}
+
+func digestHex(algo string, b []byte) (string, error) {
+	switch algo {
+	case "sha256":
+		sum := sha256.Sum256(b)
+		return hex.EncodeToString(sum[:]), nil
+	case "sha512":
+		sum := sha512.Sum512(b)
+		return hex.EncodeToString(sum[:]), nil
+	default:
+		return "", fmt.Errorf("unsupported digest algorithm '%s'", algo)
+	}
 }
 
 func (h *UrlHook) String() string {
@@ -73,11 +125,33 @@ func (h *UrlHook) Install(path string) error {
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 	b, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(path, b, 0755)
+
+	if h.digestAlgo != "" {
+		sum, err := digestHex(h.digestAlgo, b)
+		if err != nil {
+			return err
+		}
+		if sum != h.digestHex {
+			return fmt.Errorf(
+				"checksum mismatch for %s: expected %s=%s, got %s=%s",
+				h.url.String(), h.digestAlgo, h.digestHex, h.digestAlgo, sum,
+			)
+		}
+	}
+
+	if err := ioutil.WriteFile(path, b, 0755); err != nil {
+		return err
+	}
+
+	if h.digestAlgo == "" {
+		return nil
+	}
+	return ioutil.WriteFile(path+".lock", []byte(h.digestAlgo+"="+h.digestHex+"\n"), 0644)
 }
 
 type FileHook struct {
@@ -100,6 +174,191 @@ func (h *FileHook) Install(path string) error {
 	return os.Symlink(h.path, path)
 }
 
+// GitHook installs a script file out of a git repository pinned to a
+// branch, tag, or commit, e.g.
+//
+//	git+https://github.com/org/repo@v1.2.3#path/to/script.sh
+//
+// The repository is shallow-cloned into a per-commit cache under
+// <timing>.cache/ so that hooks sharing a resolved commit reuse the same
+// checkout.
+type GitHook struct {
+	repo string
+	ref  string
+	path string
+}
+
+var commitHashRe = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+func isCommitHash(ref string) bool {
+	return commitHashRe.MatchString(ref)
+}
+
+func ParseGitHook(s string) (Hook, error) {
+	if !strings.HasPrefix(s, "git+") {
+		return nil, fmt.Errorf("'%s' is not a git+ hook", s)
+	}
+	rest := strings.TrimPrefix(s, "git+")
+
+	hashIdx := strings.LastIndex(rest, "#")
+	if hashIdx < 0 {
+		return nil, fmt.Errorf("git hook '%s' is missing a #path-in-repo suffix", s)
+	}
+	path := rest[hashIdx+1:]
+	repoAndRef := rest[:hashIdx]
+
+	atIdx := strings.LastIndex(repoAndRef, "@")
+	if atIdx < 0 {
+		return nil, fmt.Errorf("git hook '%s' is missing an @ref", s)
+	}
+	repo := repoAndRef[:atIdx]
+	ref := repoAndRef[atIdx+1:]
+
+	if repo == "" || ref == "" || path == "" {
+		return nil, fmt.Errorf("git hook '%s' is malformed", s)
+	}
+	return &GitHook{repo: repo, ref: ref, path: path}, nil
+}
+
+func (h *GitHook) String() string {
+	return fmt.Sprintf("git+%s@%s#%s", h.repo, h.ref, h.path)
+}
+
+func (h *GitHook) Name() string {
+	return filepath.Base(h.path)
+}
+
+// resolveGitRef turns a branch/tag/commit ref into a concrete commit hash.
+// Commit hashes are returned as-is; anything else is resolved against the
+// remote with `git ls-remote`.
+func resolveGitRef(repo, ref string) (string, error) {
+	if isCommitHash(ref) {
+		return ref, nil
+	}
+	out, err := exec.Command("git", "ls-remote", repo, ref).Output()
+	if err != nil {
+		return "", err
+	}
+	sha, ok := pickResolvedRefSha(string(out))
+	if !ok {
+		return "", fmt.Errorf("ref '%s' not found in %s", ref, repo)
+	}
+	return sha, nil
+}
+
+// pickResolvedRefSha parses `git ls-remote` output. An annotated tag is
+// reported as two lines: the tag object's own sha, then a
+// "<sha>\t<ref>^{}" line carrying the commit it points at. Prefer the
+// peeled "^{}" line so the resolved sha is always a commit, never a tag
+// object.
+func pickResolvedRefSha(out string) (string, bool) {
+	var tagSha string
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		if strings.HasSuffix(fields[1], "^{}") {
+			return fields[0], true
+		}
+		if tagSha == "" {
+			tagSha = fields[0]
+		}
+	}
+	return tagSha, tagSha != ""
+}
+
+// ensureGitCacheClone shallow-clones repo at sha into cacheDir, reusing the
+// directory as-is if it already exists.
+func ensureGitCacheClone(repo, sha, cacheDir string) error {
+	exists, err := directoryExists(cacheDir)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return err
+	}
+
+	commands := [][]string{
+		{"init", cacheDir},
+		{"-C", cacheDir, "remote", "add", "origin", repo},
+		{"-C", cacheDir, "fetch", "--depth", "1", "origin", sha},
+		{"-C", cacheDir, "checkout", "--detach", "FETCH_HEAD"},
+	}
+	for _, args := range commands {
+		if err := exec.Command("git", args...).Run(); err != nil {
+			os.RemoveAll(cacheDir)
+			return err
+		}
+	}
+	return nil
+}
+
+// timingFromInstalledPath recovers the hook timing from an install
+// destination of the form <git-dir>/hooks/<timing>.installed/<name>.
+func timingFromInstalledPath(path string) string {
+	return strings.TrimSuffix(filepath.Base(filepath.Dir(path)), ".installed")
+}
+
+func (h *GitHook) Install(path string) error {
+	d, err := gitDirPath()
+	if err != nil {
+		return err
+	}
+
+	sha, err := resolveGitRef(h.repo, h.ref)
+	if err != nil {
+		return err
+	}
+
+	timing := timingFromInstalledPath(path)
+	cacheDir := filepath.Join(d, "hooks", timing+".cache", sha)
+	if err := ensureGitCacheClone(h.repo, sha, cacheDir); err != nil {
+		return err
+	}
+
+	src, err := filepath.Abs(filepath.Join(cacheDir, h.path))
+	if err != nil {
+		return err
+	}
+	if err := os.Symlink(src, path); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path+".lock", []byte(sha+"\n"), 0644)
+}
+
+// readLockFiles reads the <index>-<shorthash>-<name>.lock sidecars left by
+// updateHookScript, keyed by the hook's stable shortHash (not its index,
+// which shifts as disabled/enabled entries come and go) so updateHooks can
+// detect a moved ref before it clobbers the previous install.
+func readLockFiles(dir string) (map[string]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.lock"))
+	if err != nil {
+		return nil, err
+	}
+
+	locks := map[string]string{}
+	for _, m := range matches {
+		base := strings.TrimSuffix(filepath.Base(m), ".lock")
+		parts := strings.SplitN(base, "-", 3)
+		if len(parts) < 3 {
+			continue
+		}
+		hash := parts[1]
+		b, err := ioutil.ReadFile(m)
+		if err != nil {
+			return nil, err
+		}
+		locks[hash] = strings.TrimSpace(string(b))
+	}
+	return locks, nil
+}
+
 func gitDirPath() (string, error) {
 	output, err := exec.Command("git", "rev-parse", "--git-dir").Output()
 	if err != nil {
@@ -144,6 +403,7 @@ type Hook interface {
 
 func ParseHookString(s string) (Hook, error) {
 	parsers := []func(s string) (Hook, error){
+		ParseGitHook,
 		ParseUrlHook,
 		ParseFileHook,
 	}
@@ -194,7 +454,9 @@ func createRootHook() error {
 	return nil
 }
 
-func allHooks(timing string) ([]Hook, error) {
+// readHooksFileLines returns the non-blank lines of <timing>.hooks verbatim,
+// including comment/directive lines starting with "#".
+func readHooksFileLines(timing string) ([]string, error) {
 	d, err := gitDirPath()
 	if err != nil {
 		return nil, err
@@ -217,24 +479,274 @@ func allHooks(timing string) ([]Hook, error) {
 	if err := sc.Err(); err != nil {
 		return nil, err
 	}
+	return lines, nil
+}
 
-	hooks := []Hook{}
+// hookEntry is one line of a <timing>.hooks file: a hook, a disabled hook
+// (marked with a leading "#!disabled " that survives edits), or an
+// unrelated comment/directive line kept verbatim on rewrite.
+type hookEntry struct {
+	kind string // "hook", "disabled", or "comment"
+	hook Hook   // set for "hook" and "disabled"
+	raw  string // set for "comment"
+}
+
+func readHookEntries(timing string) ([]hookEntry, error) {
+	lines, err := readHooksFileLines(timing)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []hookEntry{}
 	for _, line := range lines {
-		h, err := ParseHookString(line)
-		if err != nil {
-			return nil, err
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "#!disabled "):
+			h, err := ParseHookString(strings.TrimPrefix(trimmed, "#!disabled "))
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, hookEntry{kind: "disabled", hook: h})
+		case strings.HasPrefix(trimmed, "#"):
+			entries = append(entries, hookEntry{kind: "comment", raw: line})
+		default:
+			h, err := ParseHookString(trimmed)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, hookEntry{kind: "hook", hook: h})
+		}
+	}
+	return entries, nil
+}
+
+func writeHookEntries(timing string, entries []hookEntry) error {
+	d, err := gitDirPath()
+	if err != nil {
+		return err
+	}
+
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		switch e.kind {
+		case "comment":
+			lines[i] = e.raw
+		case "disabled":
+			lines[i] = "#!disabled " + e.hook.String()
+		default:
+			lines[i] = e.hook.String()
+		}
+	}
+
+	content := strings.Join(lines, "\n")
+	if len(content) > 0 {
+		content += "\n"
+	}
+	return ioutil.WriteFile(filepath.Join(d, "hooks", timing+".hooks"), []byte(content), 0644)
+}
+
+// hookEntryPositions returns the indices of entries that are hooks
+// (enabled or disabled), in file order. A selector's numeric index refers
+// to a position in this list, matching what "list" prints.
+func hookEntryPositions(entries []hookEntry) []int {
+	positions := []int{}
+	for i, e := range entries {
+		if e.kind != "comment" {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
+
+// findHookEntryIndex resolves a <index|name|url> selector to a position in
+// entries.
+func findHookEntryIndex(entries []hookEntry, selector string) (int, error) {
+	positions := hookEntryPositions(entries)
+
+	if n, err := strconv.Atoi(selector); err == nil {
+		if n < 0 || n >= len(positions) {
+			return -1, fmt.Errorf("no hook at index %d", n)
+		}
+		return positions[n], nil
+	}
+
+	for _, pos := range positions {
+		h := entries[pos].hook
+		if h.Name() == selector || h.String() == selector || shortHash(h.String()) == selector {
+			return pos, nil
+		}
+	}
+	return -1, fmt.Errorf("no hook matching '%s'", selector)
+}
+
+func allHooks(timing string) ([]Hook, error) {
+	entries, err := readHookEntries(timing)
+	if err != nil {
+		return nil, err
+	}
+
+	hooks := []Hook{}
+	for _, e := range entries {
+		if e.kind == "hook" {
+			hooks = append(hooks, e.hook)
 		}
-		hooks = append(hooks, h)
 	}
 	return hooks, nil
 }
 
-func updateHooks(timing string) error {
+// uninstallHook removes the hook matching selector from <timing>.hooks and
+// reinstalls the remaining ones.
+func uninstallHook(timing, selector string) error {
+	entries, err := readHookEntries(timing)
+	if err != nil {
+		return err
+	}
+	idx, err := findHookEntryIndex(entries, selector)
+	if err != nil {
+		return err
+	}
+
+	entries = append(entries[:idx], entries[idx+1:]...)
+	if err := writeHookEntries(timing, entries); err != nil {
+		return err
+	}
+	return updateHooks(timing, false, false)
+}
+
+// setHookEnabled toggles the "#!disabled " marker on the hook matching
+// selector and reinstalls so <timing>.installed reflects the new set.
+func setHookEnabled(timing, selector string, enabled bool) error {
+	entries, err := readHookEntries(timing)
+	if err != nil {
+		return err
+	}
+	idx, err := findHookEntryIndex(entries, selector)
+	if err != nil {
+		return err
+	}
+
+	if enabled {
+		entries[idx].kind = "hook"
+	} else {
+		entries[idx].kind = "disabled"
+	}
+	if err := writeHookEntries(timing, entries); err != nil {
+		return err
+	}
+	return updateHooks(timing, false, false)
+}
+
+func shortHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:8]
+}
+
+// listHooks prints each hook's position, and in --long mode also its
+// stable short hash, source and enabled state.
+func listHooks(timing string, long bool) error {
+	entries, err := readHookEntries(timing)
+	if err != nil {
+		return err
+	}
+
+	i := 0
+	for _, e := range entries {
+		if e.kind == "comment" {
+			continue
+		}
+		state := "enabled"
+		if e.kind == "disabled" {
+			state = "disabled"
+		}
+		if long {
+			fmt.Printf("%d\t%s\t%s\t%s\n", i, shortHash(e.hook.String()), e.hook.String(), state)
+		} else if state == "disabled" {
+			fmt.Printf("%d: %s (disabled)\n", i, e.hook.Name())
+		} else {
+			fmt.Printf("%d: %s\n", i, e.hook.Name())
+		}
+		i++
+	}
+	return nil
+}
+
+// hookDirectives are parsed from "# key: value" header comments in a
+// <timing>.hooks file, e.g. "# timeout: 30s" or "# mode: collect".
+type hookDirectives struct {
+	timeout      time.Duration
+	failFast     bool
+	skipOnRebase bool
+}
+
+func defaultHookDirectives() hookDirectives {
+	return hookDirectives{failFast: true, skipOnRebase: true}
+}
+
+func parseHookDirectives(lines []string) (hookDirectives, error) {
+	d := defaultHookDirectives()
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		body := strings.TrimSpace(strings.TrimPrefix(trimmed, "#"))
+		parts := strings.SplitN(body, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+
+		switch key {
+		case "timeout":
+			t, err := time.ParseDuration(value)
+			if err != nil {
+				return d, fmt.Errorf("invalid timeout directive '%s': %v", value, err)
+			}
+			d.timeout = t
+		case "mode":
+			switch value {
+			case "fail-fast":
+				d.failFast = true
+			case "collect":
+				d.failFast = false
+			default:
+				return d, fmt.Errorf("invalid mode directive '%s'", value)
+			}
+		case "skip-rebase":
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return d, fmt.Errorf("invalid skip-rebase directive '%s': %v", value, err)
+			}
+			d.skipOnRebase = b
+		}
+	}
+	return d, nil
+}
+
+// requireFrozenDigests rejects any UrlHook that has no pinned checksum, for
+// use by --frozen on install/update.
+func requireFrozenDigests(hooks []Hook) error {
+	for _, h := range hooks {
+		if uh, ok := h.(*UrlHook); ok && uh.digestAlgo == "" {
+			return fmt.Errorf("%s has no pinned checksum; refusing due to --frozen", uh.String())
+		}
+	}
+	return nil
+}
+
+func updateHooks(timing string, allowMovingRefs, frozen bool) error {
 	hooks, err := allHooks(timing)
 	if err != nil {
 		return err
 	}
 
+	if frozen {
+		if err := requireFrozenDigests(hooks); err != nil {
+			return err
+		}
+	}
+
 	d, err := gitDirPath()
 	if err != nil {
 		return err
@@ -246,6 +758,11 @@ func updateHooks(timing string) error {
 		return err
 	}
 
+	oldLocks, err := readLockFiles(installedDir)
+	if err != nil {
+		return err
+	}
+
 	matches, err := filepath.Glob(installedDir + "/*")
 	if err != nil {
 		return err
@@ -257,6 +774,22 @@ func updateHooks(timing string) error {
 	}
 
 	for i, h := range hooks {
+		gh, isGitHook := h.(*GitHook)
+		if isGitHook && !allowMovingRefs && !isCommitHash(gh.ref) {
+			if oldSha, tracked := oldLocks[shortHash(gh.String())]; tracked {
+				sha, err := resolveGitRef(gh.repo, gh.ref)
+				if err != nil {
+					return err
+				}
+				if sha != oldSha {
+					return fmt.Errorf(
+						"%s: ref '%s' moved from %s to %s; pass --allow-moving-refs to follow it",
+						gh.String(), gh.ref, oldSha, sha,
+					)
+				}
+			}
+		}
+
 		err := updateHookScript(timing, i, h)
 		if err != nil {
 			return err
@@ -277,18 +810,24 @@ func updateHookScript(timing string, i int, h Hook) error {
 		return err
 	}
 
-	path := filepath.Join(d, fmt.Sprintf("%d-%s", i, h.Name()))
+	path := filepath.Join(d, fmt.Sprintf("%d-%s-%s", i, shortHash(h.String()), h.Name()))
 
 	fmt.Printf("installing %s as %s\n", h.Name(), path)
 	return h.Install(path)
 }
 
-func installHook(timing, s string) error {
+func installHook(timing, s string, frozen bool) error {
 	h, err := ParseHookString(s)
 	if err != nil {
 		return err
 	}
 
+	if frozen {
+		if err := requireFrozenDigests([]Hook{h}); err != nil {
+			return err
+		}
+	}
+
 	hooks, err := allHooks(timing)
 
 	err = updateHookScript(timing, len(hooks), h)
@@ -314,13 +853,76 @@ func installHook(timing, s string) error {
 	return err
 }
 
-func runTest(timing string, args []string) error {
+// timingsWithStdin lists the hook timings that githooks(5) documents as
+// receiving data on stdin, piped and closed by git itself (as opposed to
+// the invoking terminal's stdin, which is never closed).
+var timingsWithStdin = map[string]bool{
+	"pre-receive":  true,
+	"post-rewrite": true,
+}
+
+// installedScriptIndex recovers the leading "<i>-..." index from an
+// installed hook's filename, so callers can sort by install order instead
+// of filepath.Glob's lexical order.
+func installedScriptIndex(path string) int {
+	base := filepath.Base(path)
+	n, err := strconv.Atoi(strings.SplitN(base, "-", 2)[0])
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// runWithTimeout runs cmd, killing it and returning an error if it hasn't
+// finished within timeout. A non-positive timeout disables the deadline.
+func runWithTimeout(cmd *exec.Cmd, timeout time.Duration) error {
+	if timeout <= 0 {
+		return cmd.Run()
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		cmd.Process.Kill()
+		<-done
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+}
+
+type hookRunResult struct {
+	stdout []byte
+	stderr []byte
+	err    error
+}
+
+func runTest(timing string, args []string, jobs int) error {
+	lines, err := readHooksFileLines(timing)
+	if err != nil {
+		return err
+	}
+	directives, err := parseHookDirectives(lines)
+	if err != nil {
+		return err
+	}
+	if v := os.Getenv("GIT_HOOK_SKIP_REBASE"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			directives.skipOnRebase = b
+		}
+	}
+
 	progress, err := gitRebaseInProgress()
 	if err != nil {
 		return err
 	}
-	if progress {
-		fmt.Errorf("rebase in progress, skip %s hooks", timing)
+	if progress && directives.skipOnRebase {
+		fmt.Fprintf(os.Stderr, "rebase in progress, skip %s hooks\n", timing)
 		return nil
 	}
 
@@ -333,17 +935,92 @@ func runTest(timing string, args []string) error {
 	if err != nil {
 		return err
 	}
+	scripts := []string{}
+	for _, m := range matches {
+		if strings.HasSuffix(m, ".lock") {
+			continue
+		}
+		scripts = append(scripts, m)
+	}
+	// filepath.Glob sorts lexically, so "10-..." sorts before "2-..." once a
+	// timing has 10+ hooks installed. Sort by the leading index instead, to
+	// preserve install order for both output flushing and fail-fast
+	// reporting.
+	sort.Slice(scripts, func(i, j int) bool {
+		return installedScriptIndex(scripts[i]) < installedScriptIndex(scripts[j])
+	})
+
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
 
-	for _, script := range matches {
-		cmd := exec.Command(script)
-		cmd.Stderr = os.Stderr
-		cmd.Stdout = os.Stdout
-		err := cmd.Run()
+	// Only buffer stdin up front for timings git's hook contract documents
+	// as receiving piped, explicitly-closed input (see githooks(5)). Most
+	// timings inherit the invoking terminal's stdin, which git never
+	// closes; eagerly reading it there would hang ordinary interactive
+	// commands. For the piped timings, buffering once and handing each
+	// child its own reader avoids the concurrent hooks racing to read the
+	// same fd.
+	var stdin []byte
+	if timingsWithStdin[timing] {
+		stdin, err = ioutil.ReadAll(os.Stdin)
 		if err != nil {
 			return err
 		}
 	}
-	return nil
+
+	results := make([]hookRunResult, len(scripts))
+	sem := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	var aborted int32
+
+	for i, script := range scripts {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, script string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if directives.failFast && atomic.LoadInt32(&aborted) != 0 {
+				return
+			}
+
+			cmd := exec.Command(script, args...)
+			if timingsWithStdin[timing] {
+				cmd.Stdin = bytes.NewReader(stdin)
+			} else {
+				cmd.Stdin = os.Stdin
+			}
+			var stdout, stderr bytes.Buffer
+			cmd.Stdout = &stdout
+			cmd.Stderr = &stderr
+
+			runErr := runWithTimeout(cmd, directives.timeout)
+			results[i] = hookRunResult{stdout: stdout.Bytes(), stderr: stderr.Bytes(), err: runErr}
+			if runErr != nil && directives.failFast {
+				atomic.StoreInt32(&aborted, 1)
+			}
+		}(i, script)
+	}
+	wg.Wait()
+
+	failures := []string{}
+	for i, script := range scripts {
+		r := results[i]
+		os.Stdout.Write(r.stdout)
+		os.Stderr.Write(r.stderr)
+		if r.err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", filepath.Base(script), r.err))
+		}
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+	if directives.failFast {
+		return errors.New(failures[0])
+	}
+	return fmt.Errorf("%d %s hook(s) failed:\n%s", len(failures), timing, strings.Join(failures, "\n"))
 }
 
 func whichEditor() string {
@@ -404,7 +1081,7 @@ func runEdit(timing string) error {
 	}
 
 	if currModTime.After(prevModTime) {
-		return updateHooks(timing)
+		return updateHooks(timing, false, false)
 	}
 
 	return nil
@@ -423,6 +1100,46 @@ func showHookList(timing string) error {
 	return err
 }
 
+// lockHooks rewrites <timing>.hooks, adding a sha256 checksum fragment to
+// any URL hook line that doesn't already carry one.
+func lockHooks(timing string) error {
+	entries, err := readHookEntries(timing)
+	if err != nil {
+		return err
+	}
+
+	for i, e := range entries {
+		if e.kind == "comment" {
+			continue
+		}
+		uh, ok := e.hook.(*UrlHook)
+		if !ok || uh.digestAlgo != "" {
+			continue
+		}
+
+		resp, err := http.Get(uh.url.String())
+		if err != nil {
+			return err
+		}
+		b, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		sum, err := digestHex("sha256", b)
+		if err != nil {
+			return err
+		}
+
+		pinned := *uh.url
+		pinned.Fragment = "sha256=" + sum
+		entries[i].hook = &UrlHook{url: &pinned, digestAlgo: "sha256", digestHex: sum}
+		fmt.Printf("locked %s to sha256=%s\n", uh.url.String(), sum)
+	}
+
+	return writeHookEntries(timing, entries)
+}
+
 func isCorrectTiming(timing string) bool {
 	for _, t := range timings {
 		if t == timing {
@@ -465,6 +1182,10 @@ func main() {
 					Name:  `link, l`,
 					Usage: `Create symbolic link instead of copy to install a local script`,
 				},
+				cli.BoolFlag{
+					Name:  `frozen`,
+					Usage: `Refuse to install a URL hook with no pinned checksum`,
+				},
 			},
 			Action: func(c *cli.Context) {
 				timing, args, err := unshiftTiming(c)
@@ -472,7 +1193,7 @@ func main() {
 					cli.ShowAppHelp(c)
 					os.Exit(1)
 				}
-				err = installHook(timing, args[0])
+				err = installHook(timing, args[0], c.Bool("frozen"))
 				if err != nil {
 					fmt.Fprintln(os.Stderr, err)
 					os.Exit(1)
@@ -481,13 +1202,20 @@ func main() {
 		},
 		{
 			Name: "test",
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  `jobs, j`,
+					Value: runtime.NumCPU(),
+					Usage: `Number of installed hooks to run concurrently`,
+				},
+			},
 			Action: func(c *cli.Context) {
 				timing, args, err := unshiftTiming(c)
 				if err != nil {
 					cli.ShowAppHelp(c)
 					os.Exit(1)
 				}
-				err = runTest(timing, args)
+				err = runTest(timing, args, c.Int("jobs"))
 				if err != nil {
 					fmt.Fprintln(os.Stderr, err)
 					os.Exit(1)
@@ -511,13 +1239,23 @@ func main() {
 		},
 		{
 			Name: "update",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  `allow-moving-refs`,
+					Usage: `Follow a git hook whose branch/tag ref resolved to a different commit`,
+				},
+				cli.BoolFlag{
+					Name:  `frozen`,
+					Usage: `Refuse to update while any URL hook has no pinned checksum`,
+				},
+			},
 			Action: func(c *cli.Context) {
 				timing, args, err := unshiftTiming(c)
 				if err != nil || len(args) > 0 {
 					cli.ShowAppHelp(c)
 					os.Exit(1)
 				}
-				err = updateHooks(timing)
+				err = updateHooks(timing, c.Bool("allow-moving-refs"), c.Bool("frozen"))
 				if err != nil {
 					fmt.Fprintln(os.Stderr, err)
 					os.Exit(1)
@@ -539,6 +1277,87 @@ func main() {
 				}
 			},
 		},
+		{
+			Name: "list",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  `long, l`,
+					Usage: `Print index, hash, source and enabled state for each hook`,
+				},
+			},
+			Action: func(c *cli.Context) {
+				timing, args, err := unshiftTiming(c)
+				if err != nil || len(args) > 0 {
+					cli.ShowAppHelp(c)
+					os.Exit(1)
+				}
+				err = listHooks(timing, c.Bool("long"))
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+			},
+		},
+		{
+			Name: "uninstall",
+			Action: func(c *cli.Context) {
+				timing, args, err := unshiftTiming(c)
+				if err != nil || len(args) != 1 {
+					cli.ShowAppHelp(c)
+					os.Exit(1)
+				}
+				err = uninstallHook(timing, args[0])
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+			},
+		},
+		{
+			Name: "disable",
+			Action: func(c *cli.Context) {
+				timing, args, err := unshiftTiming(c)
+				if err != nil || len(args) != 1 {
+					cli.ShowAppHelp(c)
+					os.Exit(1)
+				}
+				err = setHookEnabled(timing, args[0], false)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+			},
+		},
+		{
+			Name: "enable",
+			Action: func(c *cli.Context) {
+				timing, args, err := unshiftTiming(c)
+				if err != nil || len(args) != 1 {
+					cli.ShowAppHelp(c)
+					os.Exit(1)
+				}
+				err = setHookEnabled(timing, args[0], true)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+			},
+		},
+		{
+			Name: "lock",
+			Action: func(c *cli.Context) {
+				timing, args, err := unshiftTiming(c)
+				if err != nil || len(args) > 0 {
+					cli.ShowAppHelp(c)
+					os.Exit(1)
+				}
+				err = lockHooks(timing)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+			},
+		},
 	}
 
 	app.Run(os.Args)
@@ -0,0 +1,267 @@
+package main
+
+import "testing"
+
+func TestParseGitHook(t *testing.T) {
+	h, err := ParseGitHook("git+https://github.com/org/repo@v1.2.3#path/to/script.sh")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	gh := h.(*GitHook)
+	if gh.repo != "https://github.com/org/repo" {
+		t.Errorf("repo = %q", gh.repo)
+	}
+	if gh.ref != "v1.2.3" {
+		t.Errorf("ref = %q", gh.ref)
+	}
+	if gh.path != "path/to/script.sh" {
+		t.Errorf("path = %q", gh.path)
+	}
+	if got, want := h.Name(), "script.sh"; got != want {
+		t.Errorf("Name() = %q, want %q", got, want)
+	}
+	if got, want := h.String(), "git+https://github.com/org/repo@v1.2.3#path/to/script.sh"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestParseGitHookSSHUserinfo(t *testing.T) {
+	// The ref is separated by the *last* "@", so a user@host in the
+	// ssh:// authority doesn't get mistaken for it.
+	h, err := ParseGitHook("git+ssh://git@github.com/org/repo@v1#hook.sh")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	gh := h.(*GitHook)
+	if gh.repo != "ssh://git@github.com/org/repo" {
+		t.Errorf("repo = %q", gh.repo)
+	}
+	if gh.ref != "v1" {
+		t.Errorf("ref = %q", gh.ref)
+	}
+}
+
+func TestParseGitHookErrors(t *testing.T) {
+	cases := []string{
+		"https://example.com/a.sh",             // missing git+ prefix
+		"git+https://example.com/repo@v1",      // missing #path
+		"git+https://example.com/repo#hook.sh", // missing @ref
+		"git+@v1#hook.sh",                      // empty repo
+	}
+	for _, s := range cases {
+		if _, err := ParseGitHook(s); err == nil {
+			t.Errorf("ParseGitHook(%q): expected error, got nil", s)
+		}
+	}
+}
+
+func TestIsCommitHash(t *testing.T) {
+	cases := map[string]bool{
+		"abc1234": true,
+		"0123456789abcdef0123456789abcdef01234567": true,
+		"v1.2.3": false,
+		"main":   false,
+		"short":  false,
+	}
+	for ref, want := range cases {
+		if got := isCommitHash(ref); got != want {
+			t.Errorf("isCommitHash(%q) = %v, want %v", ref, got, want)
+		}
+	}
+}
+
+func TestPickResolvedRefSha(t *testing.T) {
+	// An annotated tag: the tag object sha first, then the peeled commit.
+	out := "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa\trefs/tags/v1\n" +
+		"bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb\trefs/tags/v1^{}\n"
+	sha, ok := pickResolvedRefSha(out)
+	if !ok {
+		t.Fatalf("expected a resolved sha")
+	}
+	if sha != "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb" {
+		t.Errorf("sha = %q, want peeled commit sha", sha)
+	}
+}
+
+func TestPickResolvedRefShaBranch(t *testing.T) {
+	out := "cccccccccccccccccccccccccccccccccccccccc\trefs/heads/main\n"
+	sha, ok := pickResolvedRefSha(out)
+	if !ok {
+		t.Fatalf("expected a resolved sha")
+	}
+	if sha != "cccccccccccccccccccccccccccccccccccccccc" {
+		t.Errorf("sha = %q", sha)
+	}
+}
+
+func TestPickResolvedRefShaEmpty(t *testing.T) {
+	if _, ok := pickResolvedRefSha(""); ok {
+		t.Errorf("expected ok=false for empty ls-remote output")
+	}
+}
+
+func TestParseDigestFragment(t *testing.T) {
+	cases := []struct {
+		fragment string
+		algo     string
+		digest   string
+	}{
+		{"sha256=abcdef", "sha256", "abcdef"},
+		{"sha512=012345", "sha512", "012345"},
+	}
+	for _, c := range cases {
+		algo, digest, err := parseDigestFragment(c.fragment)
+		if err != nil {
+			t.Errorf("parseDigestFragment(%q): unexpected error: %v", c.fragment, err)
+			continue
+		}
+		if algo != c.algo || digest != c.digest {
+			t.Errorf("parseDigestFragment(%q) = (%q, %q), want (%q, %q)", c.fragment, algo, digest, c.algo, c.digest)
+		}
+	}
+}
+
+func TestParseDigestFragmentErrors(t *testing.T) {
+	cases := []string{
+		"nodigest",   // no '='
+		"md5=abcdef", // unsupported algorithm
+		"",           // empty
+	}
+	for _, s := range cases {
+		if _, _, err := parseDigestFragment(s); err == nil {
+			t.Errorf("parseDigestFragment(%q): expected error, got nil", s)
+		}
+	}
+}
+
+func TestParseUrlHookChecksumPin(t *testing.T) {
+	h, err := ParseUrlHook("https://example.com/pre-commit.sh#sha256=deadbeef")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	uh := h.(*UrlHook)
+	if uh.digestAlgo != "sha256" || uh.digestHex != "deadbeef" {
+		t.Errorf("got algo=%q digest=%q", uh.digestAlgo, uh.digestHex)
+	}
+	// String() must round-trip the pin so it survives a rewrite of the
+	// .hooks file.
+	if got, want := h.String(), "https://example.com/pre-commit.sh#sha256=deadbeef"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestParseUrlHookNoPin(t *testing.T) {
+	h, err := ParseUrlHook("https://example.com/pre-commit.sh")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	uh := h.(*UrlHook)
+	if uh.digestAlgo != "" {
+		t.Errorf("expected no digest pin, got %q", uh.digestAlgo)
+	}
+}
+
+func TestParseHookDirectivesDefaults(t *testing.T) {
+	d, err := parseHookDirectives(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.timeout != 0 || !d.failFast || !d.skipOnRebase {
+		t.Errorf("unexpected defaults: %+v", d)
+	}
+}
+
+func TestParseHookDirectives(t *testing.T) {
+	lines := []string{
+		"# timeout: 30s",
+		"# mode: collect",
+		"# skip-rebase: false",
+		"# just a comment, not a directive",
+		"https://example.com/pre-commit.sh",
+	}
+	d, err := parseHookDirectives(lines)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.timeout != 30_000_000_000 { // 30s in nanoseconds
+		t.Errorf("timeout = %v", d.timeout)
+	}
+	if d.failFast {
+		t.Errorf("expected mode: collect to disable fail-fast")
+	}
+	if d.skipOnRebase {
+		t.Errorf("expected skip-rebase: false to be honored")
+	}
+}
+
+func TestParseHookDirectivesErrors(t *testing.T) {
+	cases := [][]string{
+		{"# timeout: not-a-duration"},
+		{"# mode: bogus"},
+		{"# skip-rebase: not-a-bool"},
+	}
+	for _, lines := range cases {
+		if _, err := parseHookDirectives(lines); err == nil {
+			t.Errorf("parseHookDirectives(%v): expected error, got nil", lines)
+		}
+	}
+}
+
+func mustParseHookString(t *testing.T, s string) Hook {
+	t.Helper()
+	h, err := ParseHookString(s)
+	if err != nil {
+		t.Fatalf("ParseHookString(%q): %v", s, err)
+	}
+	return h
+}
+
+func TestFindHookEntryIndex(t *testing.T) {
+	entries := []hookEntry{
+		{kind: "comment", raw: "# timeout: 30s"},
+		{kind: "hook", hook: mustParseHookString(t, "https://example.com/pre-commit.sh")},
+		{kind: "disabled", hook: mustParseHookString(t, "https://example.com/other.sh")},
+		{kind: "hook", hook: mustParseHookString(t, "/usr/local/bin/custom.sh")},
+	}
+
+	// Index selectors number hooks only, skipping comment lines, matching
+	// what listHooks prints.
+	idx, err := findHookEntryIndex(entries, "0")
+	if err != nil || idx != 1 {
+		t.Errorf("index 0: got (%d, %v), want (1, nil)", idx, err)
+	}
+	idx, err = findHookEntryIndex(entries, "2")
+	if err != nil || idx != 3 {
+		t.Errorf("index 2: got (%d, %v), want (3, nil)", idx, err)
+	}
+
+	// A selector can also be the hook's Name() or full String().
+	idx, err = findHookEntryIndex(entries, "custom.sh")
+	if err != nil || idx != 3 {
+		t.Errorf("by name: got (%d, %v), want (3, nil)", idx, err)
+	}
+	idx, err = findHookEntryIndex(entries, "https://example.com/other.sh")
+	if err != nil || idx != 2 {
+		t.Errorf("by string: got (%d, %v), want (2, nil)", idx, err)
+	}
+
+	// The short hash shown by `list --long` must also work as a selector,
+	// so it can be copy-pasted into uninstall/disable/enable.
+	wantHash := shortHash(entries[1].hook.String())
+	idx, err = findHookEntryIndex(entries, wantHash)
+	if err != nil || idx != 1 {
+		t.Errorf("by short hash %q: got (%d, %v), want (1, nil)", wantHash, idx, err)
+	}
+}
+
+func TestFindHookEntryIndexNotFound(t *testing.T) {
+	entries := []hookEntry{
+		{kind: "hook", hook: mustParseHookString(t, "https://example.com/pre-commit.sh")},
+	}
+	if _, err := findHookEntryIndex(entries, "nope"); err == nil {
+		t.Errorf("expected error for unmatched selector")
+	}
+	if _, err := findHookEntryIndex(entries, "5"); err == nil {
+		t.Errorf("expected error for out-of-range index")
+	}
+}